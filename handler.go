@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"math"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/takumakei/slogzap/levelconv"
 	"go.uber.org/zap"
@@ -15,17 +17,38 @@ import (
 
 // Handler is an implementation of [slog.Handler] that uses a [zap.Logger] as its backend.
 type Handler struct {
-	zap   *zap.Logger
-	lvl   slog.Level
-	limit slog.Level
+	zap          *zap.Logger
+	conv         Leveler
+	limit        slog.Level
+	caller       bool
+	callerSkip   int
+	stacktraceAt slog.Level
 }
 
 var _ slog.Handler = (*Handler)(nil)
 
+// Leveler converts between [slog.Level] and [zapcore.Level].
+//
+// The default Leveler, installed unless [WithLevelConverter] is given,
+// delegates to [levelconv.ToZap] and [levelconv.ToSlog], which use a fixed
+// difference of 4 between the slog constants. Install a custom Leveler to
+// adopt a different scheme, such as slog's own gap-based custom levels.
+type Leveler interface {
+	ToZap(slog.Level) zapcore.Level
+	ToSlog(zapcore.Level) slog.Level
+}
+
+type defaultLeveler struct{}
+
+func (defaultLeveler) ToZap(lvl slog.Level) zapcore.Level  { return levelconv.ToZap(lvl) }
+func (defaultLeveler) ToSlog(lvl zapcore.Level) slog.Level { return levelconv.ToSlog(lvl) }
+
 // New creates a new [slog.Handler] using the provided [zap.Logger] and options.
 // It returns a [slog.Handler] that can be used with [slog.New].
 //
-// [levelconv.ToZap] is used to convert the [slog.Level] to [zapcore.Level].
+// [levelconv.ToZap] and [levelconv.ToSlog] are used to convert between
+// [slog.Level] and [zapcore.Level], unless a different [Leveler] is
+// installed with [WithLevelConverter].
 //
 // You can specify a limit using the [WithLimit] option.
 // The limit represents the limit of the conversion.
@@ -37,12 +60,18 @@ var _ slog.Handler = (*Handler)(nil)
 // The handler changes the level from a higher level to the limiting level.
 // The level is changed but the output of higher level records is not suppressed.
 //
+// By default the handler injects the caller of the logging call and adds a
+// stacktrace for records at [slog.LevelError] or above. Use [WithCaller],
+// [WithStacktraceAt], and [WithCallerSkip] to change this.
+//
 // See [WithLimit].
 func New(logger *zap.Logger, options ...Option) slog.Handler {
 	h := &Handler{
-		zap:   logger,
-		lvl:   levelconv.ToSlog(logger.Level()),
-		limit: slog.Level(math.MaxInt),
+		zap:          logger,
+		conv:         defaultLeveler{},
+		limit:        slog.Level(math.MaxInt),
+		caller:       true,
+		stacktraceAt: slog.LevelError,
 	}
 	for _, o := range options {
 		o.apply(h)
@@ -68,11 +97,36 @@ func (h *Handler) Logger() *zap.Logger {
 	return h.zap
 }
 
+// unknownLevel is returned by [Handler.Level] when none of the known
+// [zapcore.Level] values are enabled on the underlying core, meaning the
+// handler is effectively disabled for every level.
+const unknownLevel = slog.Level(math.MaxInt)
+
 // Level returns the minimum record level that will be logged.
 // The handler discards records with lower levels.
-// This value is based on the [zap.Logger.Level].
+//
+// This is computed from the underlying [zap.Logger]'s core on every call,
+// in the style of zap's LevelOf(LevelEnabler): if the core exposes an
+// optimized Level() zapcore.Level method, as [zap.NewAtomicLevel] and other
+// LevelEnablers do, that fast path is used; otherwise the known
+// [zapcore.Level] values are probed from [zapcore.DebugLevel] to
+// [zapcore.FatalLevel] and the lowest enabled one is used. This means
+// dynamic reconfiguration of the zap logger, such as through an
+// [zap.AtomicLevel], is reflected immediately.
 func (h *Handler) Level() slog.Level {
-	return h.lvl
+	core := h.zap.Core()
+	if lvler, ok := core.(interface{ Level() zapcore.Level }); ok {
+		if lvl := lvler.Level(); lvl >= zapcore.DebugLevel && lvl <= zapcore.FatalLevel {
+			return h.conv.ToSlog(lvl)
+		}
+		return unknownLevel
+	}
+	for lvl := zapcore.DebugLevel; lvl <= zapcore.FatalLevel; lvl++ {
+		if core.Enabled(lvl) {
+			return h.conv.ToSlog(lvl)
+		}
+	}
+	return unknownLevel
 }
 
 // Limit returns the maximum level that will be logged.
@@ -86,17 +140,38 @@ func (h *Handler) Limit() slog.Level {
 // It returns true if the handler is enabled for the given level,
 // determined by the underlying [zap.Logger] and limit option.
 func (h *Handler) Enabled(_ context.Context, lvl slog.Level) bool {
-	return h.lvl <= lvl
+	return h.Level() <= lvl
 }
 
+// callerSkipOffset accounts for the frames between [runtime.Callers] and the
+// caller of the slog API: runtime.Callers itself, Handle, slog.Logger's
+// internal log method, and the public API method (Info, Error, Log, ...)
+// the user actually called.
+const callerSkipOffset = 4
+
 // Handle implements [slog.Handler.Handle].
 // It processes the slog.Record and writes it to the underlying [zap.Logger].
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
-	if ce := h.zap.Check(levelconv.ToZap(min(h.limit, r.Level)), r.Message); ce != nil {
+	if ce := h.zap.Check(h.conv.ToZap(min(h.limit, r.Level)), r.Message); ce != nil {
 		ce.Time = r.Time
-		if f := runtime.FuncForPC(r.PC); f != nil {
-			file, line := f.FileLine(r.PC)
-			ce.Caller = zapcore.NewEntryCaller(r.PC, file, line, true)
+		if h.caller {
+			pc := r.PC
+			if h.callerSkip != 0 {
+				var pcs [1]uintptr
+				if n := runtime.Callers(callerSkipOffset+h.callerSkip, pcs[:]); n > 0 {
+					pc = pcs[0]
+				}
+			}
+			// pc is a return address from runtime.Callers (r.PC is documented
+			// to come from the same source), so it must be resolved with
+			// [runtime.CallersFrames], not [runtime.FuncForPC], to get the
+			// correct file and line for an inlined or tail call.
+			if frame, _ := runtime.CallersFrames([]uintptr{pc}).Next(); frame.PC != 0 {
+				ce.Caller = zapcore.NewEntryCaller(pc, frame.File, frame.Line, true)
+			}
+		}
+		if r.Level >= h.stacktraceAt {
+			ce.Stack = takeStacktrace(callerSkipOffset + h.callerSkip)
 		}
 		fields := make([]zapcore.Field, 0, r.NumAttrs())
 		r.Attrs(func(attr slog.Attr) bool {
@@ -108,6 +183,37 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	return nil
 }
 
+// takeStacktrace formats the current goroutine's stack, skipping the given
+// number of innermost frames, in the style of zap's own stacktrace capture.
+// skip uses the same frame-counting convention as the direct
+// [runtime.Callers] call above; the +1 compensates for takeStacktrace's own
+// stack frame sitting between that convention and its call to
+// [runtime.Callers].
+func takeStacktrace(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	if n == 0 {
+		return ""
+	}
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
 // WithAttrs implements [slog.Handler.WithAttrs].
 // It returns a new Handler with the given attributes added to the logger.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
@@ -135,9 +241,70 @@ func (h *Handler) clone() *Handler {
 	return &o
 }
 
-// toField converts a slog.Attr to a zapcore.Field.
+// maxLogValuerDepth bounds the number of times toField will chase a
+// [slog.LogValuer] into the value it resolves to, guarding against a
+// LogValuer that resolves to itself.
+const maxLogValuerDepth = 5
+
+// toField converts a slog.Attr to a zapcore.Field, preferring zap's
+// type-specific fields over the generic [zap.Any] so that structured
+// encoders, error verifiers, and [zapcore.ObjectMarshaler] get the encoding
+// fidelity they expect.
 func toField(attr slog.Attr) zapcore.Field {
-	return zap.Any(attr.Key, attr.Value.Any())
+	return toFieldDepth(attr, maxLogValuerDepth)
+}
+
+func toFieldDepth(attr slog.Attr, depth int) zapcore.Field {
+	key, value := attr.Key, attr.Value
+	switch value.Kind() {
+	case slog.KindString:
+		return zap.String(key, value.String())
+	case slog.KindInt64:
+		return zap.Int64(key, value.Int64())
+	case slog.KindUint64:
+		return zap.Uint64(key, value.Uint64())
+	case slog.KindFloat64:
+		return zap.Float64(key, value.Float64())
+	case slog.KindBool:
+		return zap.Bool(key, value.Bool())
+	case slog.KindDuration:
+		return zap.Duration(key, value.Duration())
+	case slog.KindTime:
+		return zap.Time(key, value.Time())
+	case slog.KindGroup:
+		marshaler := groupMarshaler(value.Group())
+		if key == "" {
+			// A group with an empty key is inlined into the parent, per slog's rule.
+			return zap.Inline(marshaler)
+		}
+		return zap.Object(key, marshaler)
+	case slog.KindLogValuer:
+		if depth <= 0 {
+			return zap.Any(key, value.Any())
+		}
+		resolved := value.Resolve()
+		if err, ok := resolved.Any().(error); ok {
+			if key == "" {
+				return zap.Error(err)
+			}
+			return zap.NamedError(key, err)
+		}
+		return toFieldDepth(slog.Attr{Key: key, Value: resolved}, depth-1)
+	default:
+		return zap.Any(key, value.Any())
+	}
+}
+
+// groupMarshaler encodes the attrs of a [slog.KindGroup] value as fields on
+// whichever [zapcore.ObjectEncoder] it is given, so it can be nested with
+// [zap.Object] or inlined into the parent with [zap.Inline].
+type groupMarshaler []slog.Attr
+
+func (g groupMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		toFieldDepth(attr, maxLogValuerDepth).AddTo(enc)
+	}
+	return nil
 }
 
 // Option is an interface for applying options to a [Handler].
@@ -157,5 +324,73 @@ func WithLimit(limit zapcore.Level) Option {
 type withLimit zapcore.Level
 
 func (o withLimit) apply(h *Handler) {
-	h.limit = levelconv.ToSlog(zapcore.Level(o))
+	h.limit = h.conv.ToSlog(zapcore.Level(o))
+}
+
+// WithLevelConverter returns an Option that installs conv as the [Leveler]
+// used to convert between [slog.Level] and [zapcore.Level].
+//
+// Apply this option before any option whose conversion depends on it, such
+// as [WithLimit], since options are applied in order.
+//
+// See [New].
+func WithLevelConverter(conv Leveler) Option {
+	return withLevelConverter{conv}
+}
+
+type withLevelConverter struct {
+	conv Leveler
+}
+
+func (o withLevelConverter) apply(h *Handler) {
+	h.conv = o.conv
+}
+
+// WithCaller returns an Option that enables or disables injecting the
+// caller of the logging call into the record, mirroring zap's
+// [zap.AddCaller]. It defaults to enabled; disable it if the wrapped
+// [zap.Logger] is already configured with zap.AddCaller, to avoid doing the
+// work twice.
+//
+// See [New].
+func WithCaller(enabled bool) Option {
+	return withCaller(enabled)
+}
+
+type withCaller bool
+
+func (o withCaller) apply(h *Handler) {
+	h.caller = bool(o)
+}
+
+// WithStacktraceAt returns an Option that sets the level at or above which
+// a stacktrace is captured and attached to the record, mirroring zap's
+// [zap.AddStacktrace]. It defaults to [slog.LevelError].
+//
+// See [New].
+func WithStacktraceAt(lvl slog.Level) Option {
+	return withStacktraceAt(lvl)
+}
+
+type withStacktraceAt slog.Level
+
+func (o withStacktraceAt) apply(h *Handler) {
+	h.stacktraceAt = slog.Level(o)
+}
+
+// WithCallerSkip returns an Option that adds skip additional frames when
+// determining the caller and stacktrace of a record. Use this when the
+// returned [slog.Handler] is itself wrapped by another layer, such as a
+// helper function or [slog.NewLogLogger], so that the reported file and
+// line point at the real call site instead of the wrapper.
+//
+// See [New].
+func WithCallerSkip(skip int) Option {
+	return withCallerSkip(skip)
+}
+
+type withCallerSkip int
+
+func (o withCallerSkip) apply(h *Handler) {
+	h.callerSkip = int(o)
 }
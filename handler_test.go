@@ -3,9 +3,14 @@ package slogzap_test
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"math"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/takumakei/slogzap"
 	"go.uber.org/zap"
@@ -101,6 +106,189 @@ func TestHandlerLevel(t *testing.T) {
 	}
 }
 
+func TestHandlerLevelDynamic(t *testing.T) {
+	atom := zap.NewAtomicLevelAt(zapcore.WarnLevel)
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&bytes.Buffer{}), atom)
+	h := slogzap.New(zap.New(core)).(*slogzap.Handler)
+	if h.Level() != slog.LevelWarn {
+		t.Error("should be WARN, got ", h.Level())
+	}
+
+	atom.SetLevel(zapcore.DebugLevel)
+	if h.Level() != slog.LevelDebug {
+		t.Error("should reflect the lowered AtomicLevel, got ", h.Level())
+	}
+}
+
+type collapseLeveler struct{}
+
+func (collapseLeveler) ToZap(lvl slog.Level) zapcore.Level {
+	if lvl >= slog.LevelError {
+		return zapcore.ErrorLevel
+	}
+	return zapcore.DebugLevel
+}
+
+func (collapseLeveler) ToSlog(lvl zapcore.Level) slog.Level {
+	if lvl >= zapcore.ErrorLevel {
+		return slog.LevelError
+	}
+	return slog.LevelDebug
+}
+
+func TestWithLevelConverter(t *testing.T) {
+	h := slogzap.New(zap.NewExample(), slogzap.WithLevelConverter(collapseLeveler{})).(*slogzap.Handler)
+	if h.Level() != slog.LevelDebug {
+		t.Error("should be DEBUG, got ", h.Level())
+	}
+
+	a := &bytes.Buffer{}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(a), zap.WarnLevel)
+	log := slog.New(slogzap.New(zap.New(core), slogzap.WithLevelConverter(collapseLeveler{})))
+	log.Warn("warn") // collapses to zapcore.DebugLevel, below the core's WarnLevel enabler
+	log.Log(context.TODO(), slog.LevelError, "error")
+	if n := bytes.Count(a.Bytes(), []byte("\n")); n != 1 {
+		t.Errorf("expected only the error record to be written, got %d lines", n)
+	}
+}
+
+type errValuer struct{ err error }
+
+func (e errValuer) LogValue() slog.Value { return slog.AnyValue(e.err) }
+
+func ExampleNew_typedFields() {
+	log := slog.New(slogzap.New(zap.NewExample()))
+	log.Info("typed", "s", "str", "n", 7, "d", 2*time.Second)
+	log.Info("group", slog.Group("g", slog.String("a", "1"), slog.Int("b", 2)))
+	log.Info("inline", slog.Group("", slog.String("a", "1")))
+	log.Info("logvaluer", "err", errValuer{errors.New("boom")})
+	// Output:
+	// {"level":"info","msg":"typed","s":"str","n":7,"d":"2s"}
+	// {"level":"info","msg":"group","g":{"a":"1","b":2}}
+	// {"level":"info","msg":"inline","a":"1"}
+	// {"level":"info","msg":"logvaluer","err":"boom"}
+}
+
+func newTestCore(buf *bytes.Buffer) *zap.Logger {
+	zc := zapcore.EncoderConfig{
+		MessageKey:    "msg",
+		LevelKey:      "level",
+		CallerKey:     "caller",
+		StacktraceKey: "stacktrace",
+		EncodeLevel:   zapcore.LowercaseLevelEncoder,
+		EncodeCaller:  zapcore.ShortCallerEncoder,
+	}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zc), zapcore.AddSync(buf), zap.DebugLevel)
+	return zap.New(core)
+}
+
+func TestWithCaller(t *testing.T) {
+	t.Run("enabled by default", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := slog.New(slogzap.New(newTestCore(buf)))
+		log.Info("info")
+		if !strings.Contains(buf.String(), `"caller":"`) {
+			t.Errorf("expected a caller field, got %s", buf.String())
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := slog.New(slogzap.New(newTestCore(buf), slogzap.WithCaller(false)))
+		log.Info("info")
+		if strings.Contains(buf.String(), `"caller":"`) {
+			t.Errorf("expected no caller field, got %s", buf.String())
+		}
+	})
+}
+
+func TestWithStacktraceAt(t *testing.T) {
+	t.Run("default at error", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := slog.New(slogzap.New(newTestCore(buf)))
+		log.Info("info")
+		log.Error("error")
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if strings.Contains(lines[0], `"stacktrace":`) {
+			t.Errorf("expected no stacktrace on the info record, got %s", lines[0])
+		}
+		if !strings.Contains(lines[1], `"stacktrace":`) {
+			t.Errorf("expected a stacktrace on the error record, got %s", lines[1])
+		}
+	})
+	t.Run("raised threshold suppresses it", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log := slog.New(slogzap.New(newTestCore(buf), slogzap.WithStacktraceAt(slog.LevelError+8)))
+		log.Error("error")
+		if strings.Contains(buf.String(), `"stacktrace":`) {
+			t.Errorf("expected no stacktrace, got %s", buf.String())
+		}
+	})
+}
+
+// realCallSite logs at its own call site and returns the line of the
+// log.Error call, for comparing against the caller/stacktrace it produces.
+func realCallSite(log *slog.Logger) int {
+	_, _, callersLine, _ := runtime.Caller(0)
+	log.Error("boom")
+	return callersLine + 1
+}
+
+// wrapperLog is a one-line indirection used to test that [slogzap.WithCallerSkip]
+// skips past it to the real caller below.
+func wrapperLog(log *slog.Logger) {
+	log.Error("boom")
+}
+
+func TestWithCallerLocation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := slog.New(slogzap.New(newTestCore(buf)))
+	wantLine := realCallSite(log)
+
+	want := fmt.Sprintf("handler_test.go:%d", wantLine)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected caller to point at %s, got %s", want, buf.String())
+	}
+}
+
+func TestWithStacktraceAtLocation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := slog.New(slogzap.New(newTestCore(buf)))
+	wantLine := realCallSite(log)
+
+	i := strings.Index(buf.String(), `"stacktrace":"`)
+	if i < 0 {
+		t.Fatalf("expected a stacktrace field, got %s", buf.String())
+	}
+	stack := buf.String()[i+len(`"stacktrace":"`):]
+	parts := strings.SplitN(stack, `\n`, 3)
+	if len(parts) < 2 {
+		t.Fatalf("expected at least one stacktrace frame, got %s", stack)
+	}
+	fn, loc := parts[0], parts[1]
+
+	wantFn, wantLoc := "realCallSite", fmt.Sprintf("handler_test.go:%d", wantLine)
+	if !strings.Contains(fn, wantFn) {
+		t.Errorf("expected the first stacktrace frame to be %s, got %s", wantFn, fn)
+	}
+	if !strings.HasSuffix(loc, wantLoc) {
+		t.Errorf("expected the first stacktrace frame to end at %s, got %s", wantLoc, loc)
+	}
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := slog.New(slogzap.New(newTestCore(buf), slogzap.WithCallerSkip(1)))
+
+	_, _, callersLine, _ := runtime.Caller(0)
+	wrapperLog(log)
+	wantLine := callersLine + 1
+
+	want := fmt.Sprintf("handler_test.go:%d", wantLine)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected WithCallerSkip(1) to skip past wrapperLog to %s, got %s", want, buf.String())
+	}
+}
+
 func TestHandlerLimit(t *testing.T) {
 	t.Run("default", func(t *testing.T) {
 		h := slogzap.New(zap.NewExample()).(*slogzap.Handler)